@@ -7,7 +7,8 @@ export default defineConfig({
     port: 5173,
     proxy: {
       '/api/auth': { target: 'http://localhost:4000', changeOrigin: true },
-      '/api/tasks': { target: 'http://localhost:8000', changeOrigin: true }
+      '/api/tasks': { target: 'http://localhost:8000', changeOrigin: true },
+      '/api/v1/tasks': { target: 'http://localhost:8000', changeOrigin: true }
     }
   }
 })
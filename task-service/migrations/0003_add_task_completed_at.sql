@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+
+-- Best guess for tasks finished before the column existed
+UPDATE tasks SET completed_at = updated_at WHERE status = 'done' AND completed_at IS NULL;
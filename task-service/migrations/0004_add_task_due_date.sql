@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS due_date TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_due_date ON tasks (user_id, due_date);
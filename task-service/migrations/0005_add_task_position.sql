@@ -0,0 +1,11 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0;
+
+-- Number existing tasks per user/status column in creation order
+UPDATE tasks t SET position = ranked.pos
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id, status ORDER BY created_at, id) - 1 AS pos
+    FROM tasks
+) ranked
+WHERE t.id = ranked.id AND t.position = 0;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_status_position ON tasks (user_id, status, position);
@@ -0,0 +1,13 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS estimated_minutes INTEGER;
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS logged_minutes INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS time_entries (
+    id SERIAL PRIMARY KEY,
+    task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ended_at TIMESTAMPTZ, -- NULL while the timer is running
+    minutes INTEGER
+);
+
+-- At most one running timer per task
+CREATE UNIQUE INDEX IF NOT EXISTS idx_time_entries_running ON time_entries (task_id) WHERE ended_at IS NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'medium'
+    CONSTRAINT tasks_priority_check CHECK (priority IN ('low', 'medium', 'high'));
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_priority ON tasks (user_id, priority);
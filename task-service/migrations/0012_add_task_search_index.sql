@@ -0,0 +1,3 @@
+-- Must match the expression in the search endpoint for the index to be used
+CREATE INDEX IF NOT EXISTS idx_tasks_search ON tasks
+    USING GIN (to_tsvector('english', title || ' ' || description));
@@ -0,0 +1,4 @@
+-- Trigram similarity backs duplicate detection on create
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_title_trgm ON tasks USING GIN (title gin_trgm_ops);
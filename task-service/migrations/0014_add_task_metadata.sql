@@ -0,0 +1,5 @@
+-- Free-form key/value data for integrations; always a JSON object
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- jsonb_path_ops: smaller index, and containment (@>) is the only operator we query with
+CREATE INDEX IF NOT EXISTS idx_tasks_metadata ON tasks USING GIN (metadata jsonb_path_ops);
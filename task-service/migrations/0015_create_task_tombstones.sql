@@ -0,0 +1,11 @@
+-- One row per task that left a user's list (deleted or transferred away), so
+-- delta sync can tell clients to drop it locally
+CREATE TABLE IF NOT EXISTS task_tombstones (
+    task_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, task_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_tombstones_user_deleted ON task_tombstones (user_id, deleted_at);
+CREATE INDEX IF NOT EXISTS idx_tasks_user_updated ON tasks (user_id, updated_at);
@@ -0,0 +1,4 @@
+-- Opt-in: overdue tasks with this set have their priority raised by the escalation job
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS auto_escalate BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_auto_escalate ON tasks (due_date) WHERE auto_escalate AND status <> 'done';
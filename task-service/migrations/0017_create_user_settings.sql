@@ -0,0 +1,6 @@
+-- Per-user preferences; users themselves live in the auth service
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id INTEGER PRIMARY KEY,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+-- Pinned tasks sort ahead of the rest in GET /api/tasks
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+-- Board colour: a palette name or #rrggbb, checked (and lowercased) by the service
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS color TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_user_color ON tasks (user_id, color) WHERE color IS NOT NULL;
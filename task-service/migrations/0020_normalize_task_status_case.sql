@@ -0,0 +1,3 @@
+-- Statuses written before input was normalized may be mixed-case or padded;
+-- priority needs no fix, tasks_priority_check only ever allowed lowercase
+UPDATE tasks SET status = lower(btrim(status)) WHERE status <> lower(btrim(status));
@@ -0,0 +1,3 @@
+-- Tasks created from a template start with its priority unless overridden
+ALTER TABLE task_templates ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'medium'
+    CONSTRAINT task_templates_priority_check CHECK (priority IN ('low', 'medium', 'high'));
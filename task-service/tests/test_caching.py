@@ -0,0 +1,250 @@
+import json
+import threading
+
+import pytest
+import redis
+from prometheus_client import REGISTRY
+
+import main
+from conftest import USER_ID, wait_for_waiters
+
+# --- SingleFlight ---
+def run_flight(flight, key, fn, followers):
+    release = threading.Event()
+    results, errors = [], []
+
+    def leader_fn():
+        release.wait(5)
+        return fn()
+
+    def call(work):
+        try:
+            results.append(flight.do(key, work))
+        except Exception as e:
+            errors.append(e)
+
+    leader = threading.Thread(target=call, args=(leader_fn,))
+    leader.start()
+    while key not in flight._calls:
+        threading.Event().wait(0.001)
+    threads = [threading.Thread(target=call, args=(fn,)) for _ in range(followers)]
+    for t in threads:
+        t.start()
+    wait_for_waiters(flight, key, followers)
+    release.set()
+    for t in [leader, *threads]:
+        t.join(5)
+    return results, errors
+
+def test_single_flight_runs_once_for_concurrent_callers(counting_flights):
+    flight, calls = main.SingleFlight(), []
+
+    def load():
+        calls.append(1)
+        return "rows"
+
+    results, errors = run_flight(flight, "k", load, followers=4)
+    assert calls == [1]
+    assert results == ["rows"] * 5 and errors == []
+    assert flight._calls == {}
+
+def test_single_flight_shares_the_error_and_forgets_the_key(counting_flights):
+    flight = main.SingleFlight()
+
+    def load():
+        raise RuntimeError("db down")
+
+    results, errors = run_flight(flight, "k", load, followers=2)
+    assert results == [] and [str(e) for e in errors] == ["db down"] * 3
+    assert flight.do("k", lambda: "retried") == "retried"
+
+def test_single_flight_keys_are_independent():
+    flight = main.SingleFlight()
+    assert flight.do("a", lambda: flight.do("b", lambda: "inner")) == "inner"
+
+def test_concurrent_list_misses_make_one_database_load(client, counting_flights, monkeypatch):
+    loads, release, statuses = [], threading.Event(), []
+
+    def load_task_list(key, *args):
+        loads.append(key)
+        release.wait(5)
+        entry = {"tasks": [], "total": 0, "truncated": False, "status_counts": None}
+        main.cache_set(key, json.dumps(entry))
+        return entry
+
+    monkeypatch.setattr(main, "load_task_list", load_task_list)
+    threads = [threading.Thread(target=lambda: statuses.append(client.get("/api/tasks").status_code))
+               for _ in range(5)]
+    for t in threads:
+        t.start()
+    while not loads:
+        threading.Event().wait(0.001)
+    wait_for_waiters(main.list_flight, loads[0], 4)
+    release.set()
+    for t in threads:
+        t.join(5)
+    assert len(loads) == 1 and statuses == [200] * 5
+
+    assert client.get("/api/tasks").status_code == 200  # now a cache hit
+    assert len(loads) == 1
+
+# --- Invalidation around writes ---
+def task_row(title: str) -> dict:
+    return {"id": 1, "user_id": USER_ID, "title": title, "description": "", "status": "open",
+            "priority": "medium", "created_at": "2024-05-01T12:00:00+00:00",
+            "updated_at": "2024-05-01T12:00:00+00:00"}
+
+def test_read_overlapping_a_write_cannot_cache_the_old_rows(client, monkeypatch):
+    database = {"title": "before"}
+    read_started, write_committed = threading.Event(), threading.Event()
+
+    def load_task_list(key, *args):
+        entry = {"tasks": [task_row(database["title"])], "total": 1, "truncated": False, "status_counts": None}
+        if not read_started.is_set():
+            read_started.set()
+            write_committed.wait(5)  # the write lands between this read's query and its cache_set
+        main.cache_set(key, json.dumps(entry))
+        return entry
+
+    monkeypatch.setattr(main, "load_task_list", load_task_list)
+    seen = []
+    reader = threading.Thread(target=lambda: seen.append(client.get("/api/tasks").json()[0]["title"]))
+    reader.start()
+    assert read_started.wait(5)
+    database["title"] = "after"  # the write's transaction commits...
+    main.invalidate_tasks_cache(USER_ID)  # ...and only then bumps the version
+    write_committed.set()
+    reader.join(5)
+
+    assert seen == ["before"]  # the overlapping read answered with what it read
+    assert client.get("/api/tasks").json()[0]["title"] == "after"  # but it did not poison the cache
+
+def fail(*args, **kwargs):
+    raise redis.ConnectionError("Redis is down")
+
+def test_failed_version_bump_is_queued_and_bypasses_the_cache(fake_redis):
+    fake_redis.incr = fail
+    main.invalidate_tasks_cache(USER_ID)
+    assert main.pending_version_bumps == {USER_ID}
+    assert not main.cache_available()
+
+def test_queued_version_bump_lands_once_redis_is_back(fake_redis):
+    fake_redis.incr = fail
+    main.invalidate_tasks_cache(USER_ID)
+    del fake_redis.incr  # Redis answers again
+    assert main.flush_pending_version_bumps() == 1
+    assert main.pending_version_bumps == set()
+    assert fake_redis.get(main.cache_version_key(USER_ID)) == "1"  # what other replicas read
+
+def test_queued_version_bump_stays_queued_while_redis_is_down(fake_redis):
+    fake_redis.incr = fail
+    main.invalidate_tasks_cache(USER_ID)
+    with pytest.raises(redis.RedisError):
+        main.flush_pending_version_bumps()
+    assert main.pending_version_bumps == {USER_ID}
+
+def test_purge_answers_503_while_redis_is_down(client, fake_redis):
+    fake_redis.scan_iter = fail
+    response = client.post("/api/tasks/cache/invalidate")
+    assert response.status_code == 503
+    assert response.json()["code"] == "CACHE_UNAVAILABLE"
+    assert not main.cache_available()
+
+def test_purge_removes_the_users_entries(client, fake_redis):
+    fake_redis.set(main.rkey("tasks", USER_ID, "v0"), "[]")
+    fake_redis.set(main.rkey("tasks", USER_ID + 1, "v0"), "[]")
+    assert client.post("/api/tasks/cache/invalidate").json() == {"removed": 1}
+    assert fake_redis.exists(main.rkey("tasks", USER_ID + 1, "v0"))
+
+# --- TaskL1Cache ---
+class Clock:
+    def __init__(self, now: float = 1000.0):
+        self.now = now
+
+    def __call__(self) -> float:
+        return self.now
+
+@pytest.fixture
+def clock(monkeypatch):
+    clock = Clock()
+    monkeypatch.setattr(main.time, "monotonic", clock)
+    return clock
+
+def active_cache(size=2, ttl=5) -> main.TaskL1Cache:
+    cache = main.TaskL1Cache(size, ttl)
+    cache.active = True
+    return cache
+
+def test_l1_returns_what_was_put(clock):
+    cache = active_cache()
+    cache.put(1, 10, {"id": 10}, cache.generation(1))
+    assert cache.get(1, 10) == {"id": 10}
+    assert cache.get(2, 10) is None
+
+def test_l1_entries_expire_after_ttl(clock):
+    cache = active_cache(ttl=5)
+    cache.put(1, 10, {"id": 10}, cache.generation(1))
+    clock.now += 5
+    assert cache.get(1, 10) is None
+
+def test_l1_evicts_least_recently_used(clock):
+    cache = active_cache(size=2)
+    for task_id in (10, 11):
+        cache.put(1, task_id, {"id": task_id}, cache.generation(1))
+    cache.get(1, 10)
+    cache.put(1, 12, {"id": 12}, cache.generation(1))
+    assert cache.get(1, 11) is None
+    assert cache.get(1, 10) and cache.get(1, 12)
+
+def l1_events(event: str) -> float:
+    return REGISTRY.get_sample_value("task_l1_cache_events_total", {"event": event})
+
+def test_l1_hits_misses_and_evictions_are_exported(clock):
+    before = {event: l1_events(event) for event in ("hit", "miss", "eviction")}
+    cache = active_cache(size=1)
+    cache.put(1, 10, {"id": 10}, cache.generation(1))
+    cache.get(1, 10)
+    cache.put(1, 11, {"id": 11}, cache.generation(1))  # pushes 10 out
+    cache.get(1, 10)
+    assert {event: l1_events(event) - before[event] for event in before} == {"hit": 1, "miss": 1, "eviction": 1}
+    assert cache.report()["l1_evictions"] == 1
+
+def test_l1_drops_a_put_that_overlapped_an_invalidation(clock):
+    cache = active_cache()
+    generation = cache.generation(1)  # read starts
+    cache.invalidate_user(1)  # a write lands meanwhile
+    cache.put(1, 10, {"id": 10, "title": "stale"}, generation)
+    assert cache.get(1, 10) is None
+
+def test_l1_invalidate_user_only_touches_that_user(clock):
+    cache = active_cache(size=4)
+    cache.put(1, 10, {"id": 10}, cache.generation(1))
+    cache.put(2, 20, {"id": 20}, cache.generation(2))
+    cache.invalidate_user(1)
+    assert cache.get(1, 10) is None
+    assert cache.get(2, 20) == {"id": 20}
+
+def test_l1_clear_also_drops_puts_started_before_it(clock):
+    cache = active_cache()
+    generation = cache.generation(1)
+    cache.clear()
+    cache.put(1, 10, {"id": 10}, generation)
+    assert cache.get(1, 10) is None
+
+def test_l1_stores_nothing_until_the_listener_is_active(clock):
+    cache = main.TaskL1Cache(2, 5)
+    cache.put(1, 10, {"id": 10}, cache.generation(1))
+    assert cache.get(1, 10) is None
+
+# --- etag_matches ---
+@pytest.mark.parametrize("header, expected", [
+    (None, False),
+    ("", False),
+    ('"1-100"', True),
+    ('W/"1-100"', True),
+    ('"9-1", "1-100"', True),
+    ('"1-101"', False),
+    ("*", True),
+])
+def test_etag_matches(header, expected):
+    assert main.etag_matches(header, '"1-100"') is expected
@@ -0,0 +1,23 @@
+import os
+
+import main
+
+def test_split_sql_splits_on_trailing_semicolons():
+    script = "CREATE TABLE a (id INT);\nCREATE INDEX a_id ON a (id);\n"
+    assert main.split_sql(script) == ["CREATE TABLE a (id INT);", "CREATE INDEX a_id ON a (id);"]
+
+def test_split_sql_keeps_multi_line_statements_together():
+    script = "ALTER TABLE a\n  ADD COLUMN b TEXT,\n  ADD COLUMN c TEXT;\n"
+    assert main.split_sql(script) == ["ALTER TABLE a\n  ADD COLUMN b TEXT,\n  ADD COLUMN c TEXT;"]
+
+def test_split_sql_drops_comment_lines_and_blank_scripts():
+    assert main.split_sql("-- nothing to do\n\n  -- still nothing\n") == []
+    assert main.split_sql("-- add b\nALTER TABLE a ADD COLUMN b TEXT;") == ["ALTER TABLE a ADD COLUMN b TEXT;"]
+
+def test_split_sql_keeps_a_final_statement_without_semicolon():
+    assert main.split_sql("SELECT 1;\nSELECT 2") == ["SELECT 1;", "SELECT 2"]
+
+def test_every_migration_file_parses():
+    for name in sorted(os.listdir(main.MIGRATIONS_DIR)):
+        with open(os.path.join(main.MIGRATIONS_DIR, name)) as f:
+            assert main.split_sql(f.read()), f"{name} has no statements"
@@ -0,0 +1,149 @@
+from types import SimpleNamespace
+
+import pytest
+import redis
+from opentelemetry import trace
+from prometheus_client import REGISTRY, CollectorRegistry
+from opentelemetry.sdk.trace import TracerProvider
+
+import main
+
+@pytest.fixture
+def clock(monkeypatch):
+    now = [1000.0]
+    monkeypatch.setattr(main.time, "monotonic", lambda: now[0])
+    return now
+
+def test_breaker_opens_after_threshold_failures(clock):
+    breaker = main.CircuitBreaker(threshold=3, cooldown=30)
+    for _ in range(2):
+        breaker.record(False)
+    assert breaker.state == "closed" and breaker.allow()
+    breaker.record(False)
+    assert breaker.state == "open"
+    assert not breaker.allow()
+
+def test_breaker_success_resets_the_failure_count(clock):
+    breaker = main.CircuitBreaker(threshold=2, cooldown=30)
+    breaker.record(False)
+    breaker.record(True)
+    breaker.record(False)
+    assert breaker.state == "closed"
+
+def test_breaker_lets_one_trial_through_after_cooldown(clock):
+    breaker = main.CircuitBreaker(threshold=1, cooldown=30)
+    breaker.record(False)
+    clock[0] += 30
+    assert breaker.state == "half_open"
+    assert breaker.allow()
+    assert not breaker.allow()  # the trial is still running
+
+def test_breaker_closes_when_the_trial_succeeds(clock):
+    breaker = main.CircuitBreaker(threshold=1, cooldown=30)
+    breaker.record(False)
+    clock[0] += 30
+    breaker.allow()
+    breaker.record(True)
+    assert breaker.state == "closed" and breaker.allow()
+
+def test_breaker_reopens_for_a_full_cooldown_when_the_trial_fails(clock):
+    breaker = main.CircuitBreaker(threshold=1, cooldown=30)
+    breaker.record(False)
+    clock[0] += 30
+    breaker.allow()
+    breaker.record(False)
+    assert breaker.state == "open"
+    clock[0] += 29
+    assert not breaker.allow()
+
+# --- Daily digest ---
+class RecordingNotifier(main.Notifier):
+    def __init__(self):
+        self.events = []
+
+    def send(self, event):
+        self.events.append(event)
+
+@pytest.fixture
+def digest(monkeypatch):
+    notifier = RecordingNotifier()
+    monkeypatch.setattr(main, "notifiers", [notifier])
+    monkeypatch.setattr(main, "digest_counts", lambda user_id: SimpleNamespace(pending=4, overdue=1))
+    return notifier
+
+def test_digest_goes_through_the_notifiers(digest):
+    main.send_daily_digest(1, "me@example.com", "2024-05-01")
+    [event] = digest.events
+    assert event["type"] == "task.digest"
+    assert (event["user_id"], event["to_email"], event["pending"], event["overdue"]) == (1, "me@example.com", 4, 1)
+
+def test_digest_is_sent_once_per_day(digest):
+    for _ in range(2):
+        main.send_daily_digest(1, "me@example.com", "2024-05-01")
+    main.send_daily_digest(1, "me@example.com", "2024-05-02")
+    assert len(digest.events) == 2
+
+def test_failed_digest_is_retried_on_the_next_pass(digest, monkeypatch):
+    def down(user_id):
+        raise RuntimeError("db down")
+
+    with monkeypatch.context() as patch:
+        patch.setattr(main, "digest_counts", down)
+        with pytest.raises(RuntimeError):
+            main.send_daily_digest(1, "me@example.com", "2024-05-01")
+    main.send_daily_digest(1, "me@example.com", "2024-05-01")
+    assert len(digest.events) == 1
+
+# --- Trace context ---
+class Immediate:
+    def submit(self, fn, *args):
+        fn(*args)
+
+def test_queued_notification_continues_the_callers_trace(monkeypatch):
+    tracer = TracerProvider().get_tracer("test")
+    seen = []
+
+    class TraceNotifier(main.Notifier):
+        def send(self, event):
+            seen.append(trace.get_current_span().get_span_context().trace_id)
+
+    monkeypatch.setattr(main, "tracer", tracer)
+    monkeypatch.setattr(main, "notify_executor", Immediate())
+    monkeypatch.setattr(main, "notifiers", [TraceNotifier()])
+    with tracer.start_as_current_span("request") as span:
+        main.queue_notification({"id": 1, "user_id": 1, "priority": "low"}, "Task created", "created")
+    assert seen == [span.get_span_context().trace_id]
+
+# --- Metrics ---
+def test_queue_depths_are_exported(fake_redis, monkeypatch):
+    monkeypatch.setattr(main, "notify_pending", 3)
+    fake_redis.lpush(main.NOTIFY_DLQ_KEY, "{}", "{}")
+    assert REGISTRY.get_sample_value("notify_pending") == 3
+    assert REGISTRY.get_sample_value("notify_dlq_length") == 2
+
+def test_dlq_gauge_survives_redis_being_down(fake_redis):
+    def fail(*args):
+        raise redis.ConnectionError("Redis is down")
+
+    fake_redis.llen = fail
+    value = REGISTRY.get_sample_value("notify_dlq_length")
+    assert value != value  # NaN
+
+def test_breaker_counts_trips_from_closed_only(clock):
+    registry = CollectorRegistry()
+    trips = main.Counter("breaker_trips", "Trips", registry=registry)
+    breaker = main.CircuitBreaker(threshold=1, cooldown=30, trips=trips)
+    breaker.record(False)
+    clock[0] += 30
+    breaker.allow()
+    breaker.record(False)  # the trial failed: still the same outage
+    assert registry.get_sample_value("breaker_trips_total") == 1
+
+def test_email_breaker_state_is_exported(clock, monkeypatch):
+    breaker = main.CircuitBreaker(threshold=1, cooldown=30)
+    monkeypatch.setattr(main, "email_breaker", breaker)
+    assert REGISTRY.get_sample_value("email_breaker_state") == 0
+    breaker.record(False)
+    assert REGISTRY.get_sample_value("email_breaker_state") == 2
+    clock[0] += 30
+    assert REGISTRY.get_sample_value("email_breaker_state") == 1
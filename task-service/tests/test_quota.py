@@ -0,0 +1,85 @@
+from unittest.mock import MagicMock
+
+import pytest
+
+import main
+from conftest import USER_ID, create_task
+
+@pytest.fixture
+def quota(monkeypatch):
+    monkeypatch.setattr(main, "MAX_TASKS_PER_USER", 3)
+    monkeypatch.setattr(main, "ADMIN_USER_IDS", set())
+
+def conn_with_count(count: int) -> MagicMock:
+    conn = MagicMock()
+    conn.execute.return_value.scalar_one.return_value = count
+    return conn
+
+def test_quota_allows_the_last_free_slot(quota):
+    main.enforce_task_quota(conn_with_count(2), USER_ID)
+
+def test_quota_rejects_one_past_the_limit(quota):
+    with pytest.raises(main.ApiError) as err:
+        main.enforce_task_quota(conn_with_count(3), USER_ID)
+    assert (err.value.status_code, err.value.code) == (403, "TASK_QUOTA_EXCEEDED")
+    assert err.value.details == {"limit": 3, "count": 3}
+
+def test_quota_counts_every_task_of_a_bulk_create(quota):
+    main.enforce_task_quota(conn_with_count(1), USER_ID, adding=2)
+    with pytest.raises(main.ApiError):
+        main.enforce_task_quota(conn_with_count(2), USER_ID, adding=2)
+
+def test_quota_zero_means_unlimited(monkeypatch):
+    monkeypatch.setattr(main, "MAX_TASKS_PER_USER", 0)
+    conn = conn_with_count(10_000)
+    main.enforce_task_quota(conn, USER_ID)
+    conn.execute.assert_not_called()
+
+def test_admins_are_exempt(quota, monkeypatch):
+    monkeypatch.setattr(main, "ADMIN_USER_IDS", {USER_ID})
+    main.enforce_task_quota(conn_with_count(3), USER_ID)
+
+def test_quota_count_is_cached_until_the_next_write(quota):
+    conn = conn_with_count(1)
+    main.enforce_task_quota(conn, USER_ID)
+    main.enforce_task_quota(conn, USER_ID)
+    assert conn.execute.call_count == 1
+    main.invalidate_tasks_cache(USER_ID)
+    main.enforce_task_quota(conn, USER_ID)
+    assert conn.execute.call_count == 2
+
+def test_create_stops_at_the_quota(client, db, quota):
+    for _ in range(3):
+        create_task(client)
+    response = client.post("/api/tasks", json={"title": "One too many"})
+    assert response.status_code == 403
+    assert response.json()["code"] == "TASK_QUOTA_EXCEEDED"
+
+# --- Transfers count against the receiving user ---
+OTHER_USER_ID = USER_ID + 1
+
+def fill_other_users_quota(db) -> None:
+    with db.begin() as conn:
+        for _ in range(3):
+            main.insert_task(conn, OTHER_USER_ID, main.TaskIn(title="Theirs"))
+
+def test_transfer_stops_at_the_receivers_quota(client, db, quota):
+    task = create_task(client)
+    fill_other_users_quota(db)
+    response = client.post(f"/api/tasks/{task['id']}/transfer", json={"new_user_id": OTHER_USER_ID})
+    assert response.status_code == 403
+    assert response.json()["code"] == "TASK_QUOTA_EXCEEDED"
+    assert client.get(f"/api/tasks/{task['id']}").status_code == 200  # still ours
+
+def test_admin_transfer_counts_every_moved_task(client, db, quota, monkeypatch):
+    for _ in range(2):
+        create_task(client)
+    with db.begin() as conn:
+        main.insert_task(conn, OTHER_USER_ID, main.TaskIn(title="Theirs"))
+        main.insert_task(conn, OTHER_USER_ID, main.TaskIn(title="Theirs"))
+    monkeypatch.setattr(main, "ADMIN_USER_IDS", {USER_ID + 100})
+    main.app.dependency_overrides[main.get_user_id] = lambda: USER_ID + 100
+    response = client.post("/api/tasks/admin/transfer",
+                           json={"from_user_id": USER_ID, "to_user_id": OTHER_USER_ID})
+    assert response.status_code == 403
+    assert response.json()["details"] == {"limit": 3, "count": 2}
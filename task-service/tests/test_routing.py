@@ -0,0 +1,96 @@
+import pytest
+from fastapi.testclient import TestClient
+from prometheus_client import REGISTRY
+from sqlalchemy.exc import OperationalError
+
+import main
+
+@pytest.mark.parametrize("method", ["PUT", "POST"])
+def test_disallowed_method_is_405_listing_allowed_methods(client, method):
+    response = client.request(method, "/api/tasks/1", json={})
+    assert response.status_code == 405
+    assert response.json()["code"] == "METHOD_NOT_ALLOWED"
+    allowed = {m.strip() for m in response.headers["Allow"].split(",")}
+    assert {"GET", "PATCH", "DELETE"} <= allowed
+    assert method not in allowed
+
+def test_versioned_path_gets_the_same_405(client):
+    response = client.put("/api/v1/tasks/1", json={})
+    assert response.status_code == 405
+    assert "PATCH" in response.headers["Allow"]
+
+def test_unknown_path_is_still_404(client):
+    response = client.get("/api/nothing-here")
+    assert response.status_code == 404
+    assert response.json()["code"] == "NOT_FOUND"
+
+# --- Unexpected errors ---
+@pytest.fixture
+def lenient_client(client):
+    return TestClient(main.app, raise_server_exceptions=False)
+
+def broken_list(error: Exception):
+    def load_task_list(*args):
+        raise error
+    return load_task_list
+
+@pytest.mark.parametrize("error", [
+    RuntimeError("boom"),
+    OperationalError("SELECT 1", {}, Exception("connection refused")),
+])
+def test_unexpected_errors_get_the_envelope_and_request_id(lenient_client, monkeypatch, error):
+    monkeypatch.setattr(main, "load_task_list", broken_list(error))
+    response = lenient_client.get("/api/tasks", headers={"X-Request-ID": "req-1"})
+    assert response.status_code == 500
+    assert response.json() == {"code": "INTERNAL_ERROR", "message": "Internal server error"}
+    assert response.headers["X-Request-ID"] == "req-1"
+
+# --- Read-only mode ---
+@pytest.fixture
+def read_only(fake_redis):
+    fake_redis.set(main.READ_ONLY_KEY, "1")
+
+def test_read_only_rejects_writes(client, read_only):
+    response = client.post("/api/tasks", json={"title": "x"})
+    assert response.status_code == 503
+    assert response.json()["code"] == "READ_ONLY_MODE"
+
+def test_read_only_rejection_carries_cors_headers(client, read_only):
+    response = client.post("/api/tasks", json={"title": "x"}, headers={"Origin": main.ORIGIN})
+    assert response.status_code == 503
+    assert response.headers["Access-Control-Allow-Origin"] == main.ORIGIN
+
+def test_read_only_rejects_a_real_bulk_update_by_filter(client, read_only):
+    response = client.post("/api/tasks/bulk-update-by-filter",
+                           json={"filter": {"status": "open"}, "set": {"priority": "high"}, "confirm": True})
+    assert response.status_code == 503
+    assert response.json()["code"] == "READ_ONLY_MODE"
+
+def test_read_only_allows_a_dry_run_bulk_update_by_filter(client, db, read_only):
+    response = client.post("/api/tasks/bulk-update-by-filter",
+                           json={"filter": {"status": "open"}, "set": {"priority": "high"}, "dry_run": True})
+    assert response.status_code == 200, response.text
+    assert response.json()["dry_run"] is True
+
+def test_read_only_allows_batch_get(client, db, read_only):
+    response = client.post("/api/tasks/batch-get", json={"ids": [1]})
+    assert response.status_code == 200, response.text
+    assert response.json()["missing"] == [1]
+
+# --- Load shedding ---
+@pytest.fixture
+def saturated(monkeypatch):
+    monkeypatch.setattr(main, "MAX_IN_FLIGHT_REQUESTS", 1)
+    monkeypatch.setitem(main.load_stats, "in_flight", 1)  # one request already running
+
+def test_shed_requests_are_counted_on_metrics(client, saturated):
+    before = REGISTRY.get_sample_value("http_requests_shed_total")
+    response = client.get("/api/tasks")
+    assert response.status_code == 503
+    assert response.json()["code"] == "OVERLOADED"
+    assert REGISTRY.get_sample_value("http_requests_shed_total") == before + 1
+
+def test_metrics_are_served_while_shedding(client, saturated):
+    response = client.get("/metrics")
+    assert response.status_code == 200
+    assert "http_requests_in_flight 1.0" in response.text
@@ -0,0 +1,108 @@
+from datetime import datetime, timedelta, timezone
+
+import main
+from conftest import create_task
+
+def set_status(client, task_id: int, status: str) -> dict:
+    response = client.patch(f"/api/tasks/{task_id}/status", json={"status": status})
+    assert response.status_code == 200, response.text
+    return response.json()
+
+# --- completed_at ---
+def test_completed_at_follows_done_open_done(client, db):
+    task = create_task(client)
+    assert task["completed_at"] is None
+
+    first = set_status(client, task["id"], "done")["completed_at"]
+    assert first is not None
+    assert set_status(client, task["id"], "done")["completed_at"] == first  # already done: kept
+
+    assert set_status(client, task["id"], "open")["completed_at"] is None
+
+    second = set_status(client, task["id"], "done")["completed_at"]
+    assert datetime.fromisoformat(second) > datetime.fromisoformat(first)
+
+def test_completed_at_is_set_for_a_task_created_done(client, db):
+    assert create_task(client, status="done")["completed_at"] is not None
+
+# --- Defaults on create ---
+def test_task_in_defaults_status():
+    assert main.TaskIn(title="x").status == main.DEFAULT_TASK_STATUS == "open"
+
+def test_create_without_status_returns_the_full_open_task(client, db):
+    task = create_task(client, title="No status given")
+    assert task["status"] == "open"
+    assert task["id"] and task["created_at"] and task["updated_at"]
+    fetched = client.get(f"/api/tasks/{task['id']}").json()
+    assert fetched["status"] == "open"
+
+# --- Due-date ordering and filters ---
+def make_mixed_due_dates(client) -> None:
+    for name, due_in in [("later", "+2d"), ("none-a", None), ("sooner", "+1d"), ("none-b", None)]:
+        create_task(client, title=name, **({"due_in": due_in} if due_in else {}))
+
+def titles(response) -> list:
+    assert response.status_code == 200, response.text
+    return [t["title"] for t in response.json()]
+
+def test_due_date_sort_puts_tasks_without_due_date_last(client, db):
+    make_mixed_due_dates(client)
+    response = client.get("/api/tasks", params={"sort": "due_date"})
+    assert titles(response) == ["sooner", "later", "none-a", "none-b"]
+
+def test_due_date_sort_is_stable_across_pages(client, db):
+    make_mixed_due_dates(client)
+    pages = [titles(client.get("/api/tasks", params={"sort": "due_date", "limit": 2, "offset": offset}))
+             for offset in (0, 2)]
+    assert pages == [["sooner", "later"], ["none-a", "none-b"]]
+
+def test_due_range_filters_never_match_tasks_without_due_date(client, db):
+    make_mixed_due_dates(client)
+    for name, bound in [("due_after", "2000-01-01T00:00:00+00:00"), ("due_before", "2999-01-01T00:00:00+00:00")]:
+        response = client.get("/api/tasks", params={name: bound, "sort": "due_date"})
+        assert titles(response) == ["sooner", "later"]
+    response = client.get("/api/tasks", params={"has_due_date": "false", "sort": "due_date"})
+    assert titles(response) == ["none-a", "none-b"]
+
+# --- Past due dates ---
+def iso(offset: timedelta) -> str:
+    return (datetime.now(timezone.utc) + offset).isoformat()
+
+def test_create_accepts_due_dates_just_behind_or_ahead_of_now(client, db, past_due_rule):
+    for offset in (timedelta(seconds=-60), timedelta(seconds=60)):
+        create_task(client, due_date=iso(offset))
+
+def test_create_rejects_due_dates_past_the_grace_window(client, db, past_due_rule):
+    response = client.post("/api/tasks", json={"title": "Late", "due_date": iso(timedelta(seconds=-600))})
+    assert response.status_code == 400
+    assert response.json()["code"] == "DUE_DATE_IN_PAST"
+
+def test_update_may_backfill_a_past_due_date(client, db, past_due_rule):
+    task = create_task(client)
+    response = client.patch(f"/api/tasks/{task['id']}", json={"due_date": iso(timedelta(days=-3))})
+    assert response.status_code == 200, response.text
+
+# --- Column position on status change ---
+def test_status_change_puts_the_task_at_the_bottom_of_its_new_column(client, db):
+    done = [create_task(client, status="done") for _ in range(2)]
+    assert [t["position"] for t in done] == [0, 1]
+    task = create_task(client)
+    assert set_status(client, task["id"], "done")["position"] == 2
+    assert set_status(client, task["id"], "done")["position"] == 2  # same column: unchanged
+    assert set_status(client, task["id"], "open")["position"] == 0
+
+def test_bulk_status_appends_after_the_existing_column_without_ties(client, db):
+    create_task(client, status="done")
+    ids = [create_task(client)["id"] for _ in range(2)]
+    assert client.post("/api/tasks/bulk-status", json={"ids": ids, "status": "done"}).json() == {"updated": 2}
+    positions = [client.get(f"/api/tasks/{i}").json()["position"] for i in ids]
+    assert positions == [1, 2]  # in their old order, after the task already there
+
+# --- Due today ---
+def test_due_today_is_capped_and_flagged_truncated(client, db, monkeypatch):
+    monkeypatch.setattr(main, "MAX_TASKS_PER_RESPONSE", 2)
+    for _ in range(3):
+        create_task(client, due_in="today")
+    response = client.get("/api/tasks/today", params={"tz": "UTC"})
+    assert len(titles(response)) == 2
+    assert response.headers["X-Truncated"] == "true"
@@ -0,0 +1,40 @@
+import psycopg
+import pytest
+from sqlalchemy import text
+from sqlalchemy.exc import OperationalError
+
+import main
+
+# conftest sets REQUEST_TIMEOUT=1s, so sleeping past it trips statement_timeout
+SLOW_QUERY = text(f"SELECT pg_sleep({main.REQUEST_TIMEOUT + 1})")
+
+@pytest.fixture
+def in_request():
+    token = main.request_context.set({"request_id": "test", "user_id": None})
+    yield
+    main.request_context.reset(token)
+
+def statement_timeout(db) -> str:
+    with db.connect() as conn:
+        return conn.execute(text("SHOW statement_timeout")).scalar()
+
+def test_statement_timeout_cancels_a_slow_query(db, in_request):
+    with pytest.raises(OperationalError) as err:
+        with db.connect() as conn:
+            conn.execute(SLOW_QUERY)
+    assert isinstance(err.value.orig, psycopg.errors.QueryCanceled)
+
+def test_statement_timeout_is_per_transaction(db, in_request):
+    assert statement_timeout(db) == f"{main.REQUEST_TIMEOUT}s"
+    main.request_context.set(None)  # the same pooled connection, now used by a background job
+    assert statement_timeout(db) == "0"
+
+def test_slow_query_in_a_handler_returns_504(client, db, monkeypatch):
+    def slow_load(*args, **kwargs):
+        with db.connect() as conn:
+            conn.execute(SLOW_QUERY)
+
+    monkeypatch.setattr(main, "load_task_list", slow_load)
+    response = client.get("/api/tasks")
+    assert response.status_code == 504
+    assert response.json()["code"] == "DATABASE_TIMEOUT"
@@ -0,0 +1,212 @@
+from datetime import datetime, timedelta, timezone
+
+import pytest
+from pydantic import TypeAdapter, ValidationError
+
+import main
+
+title = TypeAdapter(main.Title)
+description = TypeAdapter(main.Description)
+
+# --- Title / Description ---
+def test_title_accepts_max_length():
+    assert title.validate_python("x" * main.TITLE_MAX_LEN) == "x" * main.TITLE_MAX_LEN
+
+def test_title_rejects_one_over_max_length():
+    with pytest.raises(ValidationError):
+        title.validate_python("x" * (main.TITLE_MAX_LEN + 1))
+
+@pytest.mark.parametrize("value", ["", "   ", "\n\t"])
+def test_title_rejects_blank(value):
+    with pytest.raises(ValidationError):
+        title.validate_python(value)
+
+def test_title_is_trimmed_before_length_check():
+    padded = "  " + "x" * main.TITLE_MAX_LEN + "  "
+    assert title.validate_python(padded) == "x" * main.TITLE_MAX_LEN
+
+def test_description_boundaries():
+    assert description.validate_python("") == ""
+    assert description.validate_python("y" * main.DESCRIPTION_MAX_LEN) == "y" * main.DESCRIPTION_MAX_LEN
+    with pytest.raises(ValidationError):
+        description.validate_python("y" * (main.DESCRIPTION_MAX_LEN + 1))
+
+def test_create_rejects_long_title_naming_the_field(client):
+    response = client.post("/api/tasks", json={"title": "x" * (main.TITLE_MAX_LEN + 1)})
+    assert response.status_code == 400
+    body = response.json()
+    assert body["code"] == "VALIDATION_ERROR"
+    assert [d["field"] for d in body["details"]] == ["body.title"]
+
+def test_update_rejects_long_description_naming_the_field(client):
+    response = client.patch("/api/tasks/1", json={"description": "y" * (main.DESCRIPTION_MAX_LEN + 1)})
+    assert response.status_code == 400
+    assert [d["field"] for d in response.json()["details"]] == ["body.description"]
+
+# --- canonical_case ---
+@pytest.mark.parametrize("value, expected", [("Done", "done"), (" HIGH ", "high"), ("open", "open")])
+def test_canonical_case_lowercases_and_trims(value, expected):
+    assert main.canonical_case(value) == expected
+
+def test_canonical_case_leaves_non_strings_alone():
+    assert main.canonical_case(None) is None
+    assert main.canonical_case(3) == 3
+
+# --- resolve_due_in ---
+NOW = datetime(2024, 5, 1, 12, 0, tzinfo=timezone.utc)
+
+@pytest.mark.parametrize("expr, expected", [
+    ("+4h", NOW + timedelta(hours=4)),
+    ("+3d", NOW + timedelta(days=3)),
+    ("+2w", NOW + timedelta(weeks=2)),
+    ("today", datetime(2024, 5, 1, 23, 59, 59, tzinfo=timezone.utc)),
+    ("Tomorrow", datetime(2024, 5, 2, 23, 59, 59, tzinfo=timezone.utc)),
+    ("next week", datetime(2024, 5, 8, 23, 59, 59, tzinfo=timezone.utc)),
+])
+def test_resolve_due_in(expr, expected):
+    assert main.resolve_due_in(expr, NOW) == expected
+
+@pytest.mark.parametrize("expr", ["3d", "+d", "+3y", "-1d", "yesterday", ""])
+def test_resolve_due_in_rejects_unknown_expressions(expr):
+    with pytest.raises(main.ApiError) as err:
+        main.resolve_due_in(expr, NOW)
+    assert (err.value.status_code, err.value.code) == (400, "INVALID_DUE_IN")
+
+# --- reject_past_due ---
+def test_reject_past_due_allows_missing_due_date():
+    main.reject_past_due(None)
+
+def test_reject_past_due_can_be_switched_off(monkeypatch):
+    monkeypatch.setattr(main, "REJECT_PAST_DUE_DATES", False)
+    main.reject_past_due(datetime(2000, 1, 1, tzinfo=timezone.utc))
+
+def test_reject_past_due_treats_naive_dates_as_utc(monkeypatch):
+    monkeypatch.setattr(main, "REJECT_PAST_DUE_DATES", True)
+    with pytest.raises(main.ApiError) as err:
+        main.reject_past_due(datetime(2000, 1, 1))
+    assert err.value.code == "DUE_DATE_IN_PAST"
+
+@pytest.mark.parametrize("offset", [timedelta(seconds=-299), timedelta(0), timedelta(seconds=1)])
+def test_reject_past_due_allows_dates_inside_the_grace_window(past_due_rule, offset):
+    main.reject_past_due(datetime.now(timezone.utc) + offset)
+
+@pytest.mark.parametrize("offset", [timedelta(seconds=-301), timedelta(days=-1)])
+def test_reject_past_due_rejects_dates_before_the_grace_window(past_due_rule, offset):
+    with pytest.raises(main.ApiError) as err:
+        main.reject_past_due(datetime.now(timezone.utc) + offset)
+    assert (err.value.status_code, err.value.code) == (400, "DUE_DATE_IN_PAST")
+
+# --- Unknown fields ---
+def test_create_rejects_a_typo_naming_the_field(client):
+    response = client.post("/api/tasks", json={"titel": "Buy milk"})
+    assert response.status_code == 400
+    fields = {d["field"]: d["message"] for d in response.json()["details"]}
+    assert "body.titel" in fields and "body.title" in fields  # the unknown one, and the missing one
+
+def test_update_rejects_a_typo_naming_the_field(client):
+    response = client.patch("/api/tasks/1", json={"descripton": "typo"})
+    assert response.status_code == 400
+    assert [d["field"] for d in response.json()["details"]] == ["body.descripton"]
+
+# --- Status and priority casing ---
+@pytest.mark.parametrize("value, expected", [
+    ("open", "open"), ("Open", "open"), ("OPEN", "open"), (" done ", "done"), ("DoNe", "done"),
+])
+def test_status_casings_map_to_the_canonical_value(value, expected):
+    assert main.TaskIn(title="x", status=value).status == expected
+    assert main.TaskStatusIn(status=value).status == expected
+    assert main.clean_status(value) == expected
+
+@pytest.mark.parametrize("value, expected", [("LOW", "low"), ("Medium", "medium"), (" hIgH", "high")])
+def test_priority_casings_map_to_the_canonical_value(value, expected):
+    assert main.TaskIn(title="x", priority=value).priority == expected
+    assert main.TaskUpdate(priority=value).priority == expected
+    assert main.clean_priority(value) == expected
+
+@pytest.mark.parametrize("value", ["IN_PROGRESS", "todo", "", "d one"])
+def test_unknown_statuses_are_rejected_after_normalising(value):
+    with pytest.raises(ValidationError):
+        main.TaskIn(title="x", status=value)
+    with pytest.raises(main.ApiError) as err:
+        main.clean_status(value)
+    assert err.value.code == "INVALID_STATUS"
+
+def test_status_filter_is_case_insensitive(client, monkeypatch):
+    seen = []
+    monkeypatch.setattr(main, "load_task_list", lambda key, user_id, filters, *args: seen.append(filters.status)
+                        or {"tasks": [], "total": 0, "truncated": False, "status_counts": None})
+    assert client.get("/api/tasks", params={"status": "DONE"}).status_code == 200
+    assert seen == ["done"]
+
+# --- Owner comes from the session only ---
+@pytest.mark.parametrize("field", ["user_id", "id"])
+def test_create_rejects_owner_or_id_in_the_body(client, field):
+    response = client.post("/api/tasks", json={"title": "Mine", field: 999})
+    assert response.status_code == 400
+    assert [d["field"] for d in response.json()["details"]] == [f"body.{field}"]
+
+@pytest.mark.parametrize("field", ["user_id", "id"])
+def test_update_rejects_owner_or_id_in_the_body(client, field):
+    response = client.patch("/api/tasks/1", json={"title": "Mine", field: 999})
+    assert response.status_code == 400
+    assert [d["field"] for d in response.json()["details"]] == [f"body.{field}"]
+
+def test_transaction_ops_accept_no_owner(client):
+    response = client.post("/api/tasks/transaction", json={"operations": [
+        {"op": "update", "id": 1, "task": {"title": "x"}, "user_id": 999}]})
+    assert response.status_code == 400
+    assert any(d["field"].endswith("user_id") for d in response.json()["details"])
+
+# --- Declarative rules on the other bodies ---
+def fields(response) -> list:
+    assert response.status_code == 400, response.text
+    assert response.json()["code"] == "VALIDATION_ERROR"
+    return [d["field"] for d in response.json()["details"]]
+
+def test_attachment_rules_are_reported_per_field(client):
+    response = client.post("/api/tasks/1/attachments", json={
+        "filename": " ", "url": "ftp://files.example.com/a", "size": -1, "content_type": "x" * 256})
+    assert fields(response) == ["body.filename", "body.url", "body.size", "body.content_type"]
+
+def test_attachment_is_trimmed():
+    attachment = main.AttachmentIn(filename=" notes.txt ", url=" https://files.example.com/n ")
+    assert (attachment.filename, attachment.url) == ("notes.txt", "https://files.example.com/n")
+
+def test_webhook_rules_are_reported_per_field(client):
+    response = client.post("/api/tasks/webhooks", json={
+        "url": "https://hooks.example.com/" + "x" * main.URL_MAX_LEN, "events": ["task.created", "task.exploded"],
+        "secret": "s" * (main.WEBHOOK_SECRET_MAX_LEN + 1)})
+    assert fields(response) == ["body.url", "body.events.1", "body.secret"]
+
+def test_webhook_events_must_not_be_empty_and_are_deduplicated(client):
+    assert fields(client.post("/api/tasks/webhooks", json={"url": "https://hooks.example.com", "events": []})) \
+        == ["body.events"]
+    hook = main.WebhookIn(url="https://hooks.example.com", events=["task.created", "task.created"])
+    assert hook.events == ["task.created"]
+
+def test_reorder_rejects_a_negative_position(client):
+    assert fields(client.patch("/api/tasks/1/reorder", json={"position": -1})) == ["body.position"]
+
+@pytest.mark.parametrize("count", [0, main.TRANSACTION_MAX_OPS + 1])
+def test_transaction_op_count_is_bounded(client, count):
+    ops = [{"op": "delete", "id": i} for i in range(count)]
+    assert fields(client.post("/api/tasks/transaction", json={"operations": ops})) == ["body.operations"]
+
+@pytest.mark.parametrize("duration", ["0m", "-5m", "soon"])
+def test_snooze_rejects_a_non_positive_duration(client, duration):
+    assert fields(client.post("/api/tasks/1/snooze", json={"duration": duration})) == ["body.duration"]
+
+def test_snooze_duration_is_parsed_to_seconds():
+    assert main.SnoozeIn(duration="90m").duration == 5400
+
+# --- parse_duration ---
+@pytest.mark.parametrize("value, seconds", [
+    ("90", 90), ("90s", 90), ("5m", 300), ("1h", 3600), ("2d", 2 * 86400), ("1W", 7 * 86400),
+])
+def test_parse_duration_units(value, seconds):
+    assert main.parse_duration(value) == seconds
+
+def test_snooze_accepts_days_and_names_the_units_otherwise(client):
+    assert main.SnoozeIn(duration="3d").duration == 3 * 86400
+    response = client.post("/api/tasks/1/snooze", json={"duration": "2y"})
+    assert main.DURATION_FORMS in response.json()["details"][0]["message"]
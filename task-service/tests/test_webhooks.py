@@ -0,0 +1,52 @@
+import socket
+import threading
+from http.server import BaseHTTPRequestHandler, ThreadingHTTPServer
+
+import pytest
+
+import main
+
+def addrinfo(*addresses):
+    return lambda host, port, **kwargs: [(socket.AF_INET, socket.SOCK_STREAM, 6, "", (a, port)) for a in addresses]
+
+# --- check_webhook_target ---
+def test_target_returns_the_address_it_checked(monkeypatch):
+    monkeypatch.setattr(main, "WEBHOOK_ALLOW_PRIVATE_TARGETS", False)
+    monkeypatch.setattr(main.socket, "getaddrinfo", addrinfo("93.184.216.34"))
+    assert main.check_webhook_target("https://hooks.example.com/in") == "93.184.216.34"
+
+def test_target_is_blocked_if_any_address_is_private(monkeypatch):
+    monkeypatch.setattr(main, "WEBHOOK_ALLOW_PRIVATE_TARGETS", False)
+    monkeypatch.setattr(main.socket, "getaddrinfo", addrinfo("93.184.216.34", "10.0.0.5"))
+    with pytest.raises(main.BlockedWebhookTarget):
+        main.check_webhook_target("https://hooks.example.com/in")
+
+# --- post_webhook ---
+@pytest.fixture
+def receiver():
+    received = []
+
+    class Handler(BaseHTTPRequestHandler):
+        def do_POST(self):
+            received.append((self.headers["Host"], self.path, self.rfile.read(int(self.headers["Content-Length"]))))
+            self.send_response(302 if self.path.startswith("/moved") else 204)
+            self.end_headers()
+
+        def log_message(self, *args):
+            pass
+
+    server = ThreadingHTTPServer(("127.0.0.1", 0), Handler)
+    threading.Thread(target=server.serve_forever, daemon=True).start()
+    yield server.server_address[1], received
+    server.shutdown()
+
+def test_post_connects_to_the_pinned_address_with_the_hooks_host(receiver):
+    port, received = receiver
+    # The name never resolves: only the pinned address is dialled
+    main.post_webhook(f"http://hooks.example.invalid:{port}/in?x=1", "127.0.0.1", b"{}", {})
+    assert received == [(f"hooks.example.invalid:{port}", "/in?x=1", b"{}")]
+
+def test_post_treats_a_redirect_as_a_failed_delivery(receiver):
+    port, _ = receiver
+    with pytest.raises(main.WebhookDeliveryFailed):
+        main.post_webhook(f"http://hooks.example.invalid:{port}/moved", "127.0.0.1", b"{}", {})